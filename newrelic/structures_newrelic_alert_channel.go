@@ -1,22 +1,496 @@
 package newrelic
 
 import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
-	"log"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+	texttemplate "text/template"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/mitchellh/mapstructure"
 	"github.com/newrelic/newrelic-client-go/pkg/alerts"
 )
 
+// channelTracer, when non-nil, receives one structured JSON line per
+// alert channel expand/flatten via traceChannelConfig. Set it with
+// SetChannelTracer, or set TF_LOG_PROVIDER_NEWRELIC_CHANNELS=1 to trace
+// to stderr without changing any code.
+var channelTracer io.Writer
+
+// SetChannelTracer directs alert channel expand/flatten tracing to w.
+// Pass nil to disable tracing unless TF_LOG_PROVIDER_NEWRELIC_CHANNELS
+// is set in the environment.
+func SetChannelTracer(w io.Writer) {
+	channelTracer = w
+}
+
+// channelTraceEvent is the shape of one line emitted by traceChannelConfig.
+type channelTraceEvent struct {
+	Event               string   `json:"event"`
+	ChannelID           int      `json:"channel_id,omitempty"`
+	Type                string   `json:"type"`
+	HCLKeys             []string `json:"hcl_keys,omitempty"`
+	APIKeys             []string `json:"api_keys,omitempty"`
+	MergedSensitiveKeys []string `json:"merged_sensitive_keys,omitempty"`
+	IsImportState       bool     `json:"is_import_state,omitempty"`
+}
+
+// traceChannelConfig emits a single structured JSON line describing an
+// alert channel expand/flatten round-trip: which keys were present in
+// HCL vs. returned by the API, and which sensitive fields were merged
+// back in from prior state. It is a no-op unless SetChannelTracer was
+// called or TF_LOG_PROVIDER_NEWRELIC_CHANNELS=1 is set.
+func traceChannelConfig(event string, channelID int, channelType alerts.ChannelType, hclKeys, apiKeys, mergedSensitiveKeys []string, isImportState bool) {
+	w := channelTracer
+	if w == nil {
+		if os.Getenv("TF_LOG_PROVIDER_NEWRELIC_CHANNELS") != "1" {
+			return
+		}
+
+		w = os.Stderr
+	}
+
+	sort.Strings(hclKeys)
+	sort.Strings(apiKeys)
+	sort.Strings(mergedSensitiveKeys)
+
+	line, err := json.Marshal(channelTraceEvent{
+		Event:               event,
+		ChannelID:           channelID,
+		Type:                string(channelType),
+		HCLKeys:             hclKeys,
+		APIKeys:             apiKeys,
+		MergedSensitiveKeys: mergedSensitiveKeys,
+		IsImportState:       isImportState,
+	})
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintln(w, string(line))
+}
+
+// hclConfigKeys returns the sorted set of keys the user populated in the
+// HCL `config` block.
+func hclConfigKeys(cfg map[string]interface{}) []string {
+	keys := make([]string, 0, len(cfg))
+	for k, v := range cfg {
+		if isEmptyConfigValue(v) {
+			continue
+		}
+
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// populatedConfigurationFields returns the Go field names of c that hold
+// a non-zero value, i.e. what the API actually returned. For a channel
+// round-tripped as a preset, flattenAlertChannel passes only the fields
+// that differ from the preset's defaults, so only those are listed.
+func populatedConfigurationFields(c alerts.ChannelConfiguration) []string {
+	v := reflect.ValueOf(c)
+	t := v.Type()
+
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		if !v.Field(i).IsZero() {
+			keys = append(keys, t.Field(i).Name)
+		}
+	}
+
+	return keys
+}
+
+// channelConfigWebhook mirrors the `config` block HCL schema for
+// `webhook` alert channels.
+type channelConfigWebhook struct {
+	BaseURL       string                 `mapstructure:"base_url"`
+	AuthUsername  string                 `mapstructure:"auth_username"`
+	AuthPassword  string                 `mapstructure:"auth_password" sensitive:"true"`
+	Headers       map[string]interface{} `mapstructure:"headers"`
+	HeadersString string                 `mapstructure:"headers_string"`
+	Payload       map[string]interface{} `mapstructure:"payload"`
+	PayloadString string                 `mapstructure:"payload_string"`
+	PayloadType   string                 `mapstructure:"payload_type"`
+	TLS           []channelConfigTLS     `mapstructure:"tls"`
+
+	PayloadTemplate           string `mapstructure:"payload_template"`
+	PayloadTemplateFile       string `mapstructure:"payload_template_file"`
+	RenderTemplatesServerSide bool   `mapstructure:"render_templates_server_side"`
+}
+
+func (c channelConfigWebhook) validate() error {
+	if len(c.Payload) != 0 && c.PayloadType == "" {
+		return errors.New("payload_type is required when using payload")
+	}
+
+	if len(c.TLS) > 0 {
+		// This provider has no webhook delivery path to hand this
+		// client to yet, so today this only validates that the
+		// cert/key/CA bundle parse correctly — a bad cert/key surfaces
+		// here at plan time instead of going undetected until
+		// something does try to dial the receiver.
+		if _, err := webhookHTTPClient(c); err != nil {
+			return err
+		}
+	}
+
+	if c.PayloadTemplate != "" && c.PayloadTemplateFile != "" {
+		return errors.New("payload_template and payload_template_file are mutually exclusive")
+	}
+
+	tmplSrc, err := c.payloadTemplateSource()
+	if err != nil {
+		return err
+	}
+
+	if tmplSrc != "" {
+		if len(c.Payload) != 0 || c.PayloadString != "" {
+			return errors.New("payload_template(_file) cannot be combined with payload or payload_string")
+		}
+
+		// Only the template source is ever sent to New Relic (see
+		// expandAlertChannelConfiguration) — this provider has no
+		// delivery path that interpolates it against a real incident,
+		// regardless of render_templates_server_side. These are
+		// plan-time lints only. With render_templates_server_side set
+		// the template is only parsed, which catches bad syntax and
+		// undefined funcs but not references to undefined fields;
+		// otherwise it is also executed against a synthetic zero-value
+		// context, which catches those too.
+		if c.RenderTemplatesServerSide {
+			if _, err := parseWebhookPayloadTemplate(tmplSrc); err != nil {
+				return fmt.Errorf("invalid payload template: %w", err)
+			}
+		} else {
+			if _, err := renderWebhookPayload(tmplSrc, webhookTemplateContext{}); err != nil {
+				return fmt.Errorf("invalid payload template: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// payloadTemplateSource returns the raw template text for the payload,
+// reading payload_template_file from disk when payload_template itself
+// isn't set. It returns "" when neither is configured.
+func (c channelConfigWebhook) payloadTemplateSource() (string, error) {
+	if c.PayloadTemplate != "" {
+		return c.PayloadTemplate, nil
+	}
+
+	if c.PayloadTemplateFile != "" {
+		b, err := os.ReadFile(c.PayloadTemplateFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read payload_template_file: %w", err)
+		}
+
+		return string(b), nil
+	}
+
+	return "", nil
+}
+
+// webhookIncidentContext, webhookConditionContext, webhookPolicyContext and
+// webhookTargetContext make up webhookTemplateContext, the data available
+// to a webhook payload_template when it is rendered.
+type webhookIncidentContext struct {
+	PolicyName    string
+	ConditionName string
+	Severity      string
+	State         string
+}
+
+type webhookConditionContext struct {
+	Name string
+	Type string
+}
+
+type webhookPolicyContext struct {
+	Name string
+	ID   int
+}
+
+type webhookTargetContext struct {
+	Name string
+	Type string
+}
+
+// webhookTemplateContext is the shape renderWebhookPayload executes a
+// payload_template against. Today it is only ever populated with zero
+// values, as the plan-time lint inside channelConfigWebhook.validate —
+// this provider has no webhook delivery path, so nothing renders a
+// payload_template against a real incident yet. Only the template
+// source itself is sent to New Relic (see
+// expandAlertChannelConfiguration) regardless of
+// render_templates_server_side; a future delivery path would need to
+// populate a context of this shape before calling renderWebhookPayload.
+type webhookTemplateContext struct {
+	Incident  webhookIncidentContext
+	Condition webhookConditionContext
+	Policy    webhookPolicyContext
+	Targets   []webhookTargetContext
+	Tags      map[string]string
+}
+
+// webhookTemplateFuncs are the functions available inside a
+// `payload_template`/`payload_template_file`, beyond text/template's
+// builtins.
+func webhookTemplateFuncs() texttemplate.FuncMap {
+	return texttemplate.FuncMap{
+		"toJson": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+
+			return string(b), nil
+		},
+		"env": os.Getenv,
+		"default": func(def, val interface{}) interface{} {
+			if val == nil || val == "" {
+				return def
+			}
+
+			return val
+		},
+		"now": func() string {
+			return time.Now().UTC().Format(time.RFC3339)
+		},
+		"secret": func(path string) (string, error) {
+			return "", fmt.Errorf("no secret backend configured to resolve %q", path)
+		},
+	}
+}
+
+func parseWebhookPayloadTemplate(src string) (*texttemplate.Template, error) {
+	return texttemplate.New("payload_template").Funcs(webhookTemplateFuncs()).Parse(src)
+}
+
+// renderWebhookPayload executes a payload template against ctx. The
+// only caller today is validate()'s plan-time lint, which passes a
+// zero-value ctx purely to catch a malformed template early; nothing in
+// this provider calls it with a real incident before delivery.
+func renderWebhookPayload(tmplSrc string, ctx webhookTemplateContext) (string, error) {
+	tmpl, err := parseWebhookPayloadTemplate(tmplSrc)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, ctx); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// channelConfigTLS mirrors the `tls` nested block supported on `webhook`
+// alert channels, intended to reach webhook receivers that sit behind
+// mTLS-only ingresses. Presently this provider has no outbound webhook
+// request of its own for webhookHTTPClient to serve, so the block is
+// validated (see channelConfigWebhook.validate) and round-tripped
+// through state, but doesn't yet change how a webhook is actually
+// delivered.
+type channelConfigTLS struct {
+	ClientCertPEM      string `mapstructure:"client_cert_pem" sensitive:"true"`
+	ClientKeyPEM       string `mapstructure:"client_key_pem" sensitive:"true"`
+	CABundlePEM        string `mapstructure:"ca_bundle_pem"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+// tlsConfig builds a *tls.Config from the supplied PEM material, failing
+// fast on malformed certificates/keys rather than at call time.
+func (c channelConfigTLS) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if (c.ClientCertPEM == "") != (c.ClientKeyPEM == "") {
+		return nil, errors.New("tls.client_cert_pem and tls.client_key_pem must be set together")
+	}
+
+	if c.ClientCertPEM != "" {
+		cert, err := tls.X509KeyPair([]byte(c.ClientCertPEM), []byte(c.ClientKeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("invalid tls client certificate/key: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.CABundlePEM != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(c.CABundlePEM)) {
+			return nil, errors.New("tls.ca_bundle_pem does not contain any valid certificates")
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// webhookHTTPClient returns an *http.Client configured with the webhook's
+// tls block, or http.DefaultClient when no tls block is set. Its only
+// caller today is validate()'s PEM smoke test; wiring it into an actual
+// outbound request is left to whatever webhook delivery code this
+// provider eventually grows.
+func webhookHTTPClient(c channelConfigWebhook) (*http.Client, error) {
+	if len(c.TLS) == 0 {
+		return http.DefaultClient, nil
+	}
+
+	tlsConfig, err := c.TLS[0].tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}, nil
+}
+
+// channelConfigSlack mirrors the `config` block HCL schema for `slack`
+// alert channels.
+type channelConfigSlack struct {
+	URL     string `mapstructure:"url" sensitive:"true"`
+	Channel string `mapstructure:"channel"`
+}
+
+func (c channelConfigSlack) validate() error {
+	return nil
+}
+
+// channelConfigPagerDuty mirrors the `config` block HCL schema for
+// `pagerduty` alert channels.
+type channelConfigPagerDuty struct {
+	ServiceKey string `mapstructure:"service_key" sensitive:"true"`
+}
+
+func (c channelConfigPagerDuty) validate() error {
+	return nil
+}
+
+// channelConfigOpsGenie mirrors the `config` block HCL schema for
+// `opsgenie` alert channels.
+type channelConfigOpsGenie struct {
+	APIKey     string `mapstructure:"api_key" sensitive:"true"`
+	Teams      string `mapstructure:"teams"`
+	Tags       string `mapstructure:"tags"`
+	Recipients string `mapstructure:"recipients"`
+	Region     string `mapstructure:"region"`
+}
+
+func (c channelConfigOpsGenie) validate() error {
+	return nil
+}
+
+// channelConfigVictorOps mirrors the `config` block HCL schema for
+// `victorops` alert channels.
+type channelConfigVictorOps struct {
+	Key      string `mapstructure:"key" sensitive:"true"`
+	RouteKey string `mapstructure:"route_key"`
+}
+
+func (c channelConfigVictorOps) validate() error {
+	return nil
+}
+
+// channelConfigEmail mirrors the `config` block HCL schema for `email`
+// alert channels.
+type channelConfigEmail struct {
+	Recipients            string `mapstructure:"recipients"`
+	IncludeJSONAttachment string `mapstructure:"include_json_attachment"`
+}
+
+func (c channelConfigEmail) validate() error {
+	return nil
+}
+
+// channelConfigHipChat mirrors the `config` block HCL schema for
+// `hipchat` alert channels.
+type channelConfigHipChat struct {
+	Key     string `mapstructure:"key" sensitive:"true"`
+	Channel string `mapstructure:"channel"`
+}
+
+func (c channelConfigHipChat) validate() error {
+	return nil
+}
+
+// channelConfigCampfire mirrors the `config` block HCL schema for
+// `campfire` alert channels.
+type channelConfigCampfire struct {
+	Key     string `mapstructure:"key" sensitive:"true"`
+	Channel string `mapstructure:"channel"`
+}
+
+func (c channelConfigCampfire) validate() error {
+	return nil
+}
+
+// channelConfigUser mirrors the `config` block HCL schema for `user`
+// alert channels.
+type channelConfigUser struct {
+	UserID string `mapstructure:"user_id"`
+}
+
+func (c channelConfigUser) validate() error {
+	return nil
+}
+
 func expandAlertChannel(d *schema.ResourceData) (*alerts.Channel, error) {
 	channel := alerts.Channel{
 		Name: d.Get("name").(string),
-		Type: alerts.ChannelType(d.Get("type").(string)),
 	}
 
+	if presetName, presetOk := d.GetOk("preset"); presetOk {
+		preset, ok := channelPresets[presetName.(string)]
+		if !ok {
+			return nil, fmt.Errorf("unknown alert channel preset: %s", presetName)
+		}
+
+		channel.Type = preset.Type
+		channel.Configuration = preset.Default()
+
+		if config, configOk := d.GetOk("config"); configOk {
+			var channelConfig map[string]interface{}
+
+			x := config.([]interface{})
+			if len(x) > 0 && x[0] != nil {
+				channelConfig = x[0].(map[string]interface{})
+			}
+
+			overrides, err := expandAlertChannelConfiguration(channel.Type, channelConfig)
+			if err != nil {
+				return nil, err
+			}
+
+			channel.Configuration = mergeChannelConfiguration(channel.Configuration, *overrides)
+		}
+
+		return &channel, nil
+	}
+
+	channel.Type = alerts.ChannelType(d.Get("type").(string))
+
 	config, configOk := d.GetOk("config")
 
 	if !configOk {
@@ -33,7 +507,7 @@ func expandAlertChannel(d *schema.ResourceData) (*alerts.Channel, error) {
 			}
 		}
 
-		c, err := expandAlertChannelConfiguration(channelConfig)
+		c, err := expandAlertChannelConfiguration(channel.Type, channelConfig)
 		if err != nil {
 			return nil, err
 		}
@@ -41,117 +515,427 @@ func expandAlertChannel(d *schema.ResourceData) (*alerts.Channel, error) {
 		channel.Configuration = *c
 	}
 
-	err := validateChannelConfiguration(channel.Configuration)
-	if err != nil {
-		return nil, err
-	}
-
 	return &channel, nil
 }
 
-//nolint:gocyclo
-func expandAlertChannelConfiguration(cfg map[string]interface{}) (*alerts.ChannelConfiguration, error) {
-	config := alerts.ChannelConfiguration{}
+// channelPreset is a battle-tested default alerts.ChannelConfiguration for
+// a well-known integration, keyed by a `preset` name such as "slack-v2".
+// A user only needs to supply the secret bits (routing key, webhook URL
+// suffix, channel name, ...) via `config`; expandAlertChannel fills in
+// the rest from Default() and lets the user-supplied config override it.
+type channelPreset struct {
+	Type    alerts.ChannelType
+	Default func() alerts.ChannelConfiguration
+}
 
-	if apiKey, ok := cfg["api_key"]; ok {
-		config.APIKey = apiKey.(string)
-	}
+var channelPresets = map[string]channelPreset{
+	"slack-v2": {
+		Type:    "slack",
+		Default: func() alerts.ChannelConfiguration { return alerts.ChannelConfiguration{} },
+	},
+	"pagerduty-events-v2": {
+		Type:    "pagerduty",
+		Default: func() alerts.ChannelConfiguration { return alerts.ChannelConfiguration{} },
+	},
+	"opsgenie-eu": {
+		Type: "opsgenie",
+		Default: func() alerts.ChannelConfiguration {
+			return alerts.ChannelConfiguration{Region: "EU"}
+		},
+	},
+	"msteams-adaptive-card": {
+		Type: "webhook",
+		Default: func() alerts.ChannelConfiguration {
+			// Static text, not a payload_template: this provider has no
+			// delivery path that renders a payload_template against a
+			// real incident (see webhookTemplateContext), so anything
+			// with `{{ ... }}` markup here would be sent to Teams
+			// verbatim. Override `config.payload` with incident detail
+			// New Relic fills in server-side, if needed.
+			return alerts.ChannelConfiguration{
+				PayloadType: "json",
+				Headers: map[string]interface{}{
+					"Content-Type": "application/json",
+				},
+				Payload: map[string]interface{}{
+					"type": "message",
+					"attachments": []interface{}{
+						map[string]interface{}{
+							"contentType": "application/vnd.microsoft.card.adaptive",
+							"content": map[string]interface{}{
+								"type":    "AdaptiveCard",
+								"version": "1.4",
+								"body": []interface{}{
+									map[string]interface{}{
+										"type": "TextBlock",
+										"text": "A New Relic alert has fired.",
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+		},
+	},
+}
+
+// mergeChannelConfiguration overlays any non-zero field of overrides onto
+// base, so a preset's defaults can be selectively overridden by
+// user-supplied config.
+func mergeChannelConfiguration(base, overrides alerts.ChannelConfiguration) alerts.ChannelConfiguration {
+	bv := reflect.ValueOf(&base).Elem()
+	ov := reflect.ValueOf(overrides)
+
+	for i := 0; i < ov.NumField(); i++ {
+		field := ov.Field(i)
+		if field.IsZero() {
+			continue
+		}
 
-	if authPassword, ok := cfg["auth_password"]; ok {
-		config.AuthPassword = authPassword.(string)
+		bv.Field(i).Set(field)
 	}
 
-	if authUsername, ok := cfg["auth_username"]; ok {
-		config.AuthUsername = authUsername.(string)
+	return base
+}
+
+// matchChannelPreset reports whether presetName is a known preset for
+// channelType whose fixed (preset-defined) fields still match c, so
+// flattenAlertChannel can round-trip the channel as that preset name
+// plus only the fields the user overrode, keeping state compact.
+//
+// presetName must come from the resource's own prior state/config, not
+// be inferred from c's shape: several presets (e.g. "slack-v2",
+// "pagerduty-events-v2") have an all-zero Default(), which would
+// otherwise make every plain slack/pagerduty channel match trivially and
+// get `preset` written into state even though the user never set it.
+func matchChannelPreset(presetName string, channelType alerts.ChannelType, c alerts.ChannelConfiguration) (alerts.ChannelConfiguration, bool) {
+	if presetName == "" {
+		return alerts.ChannelConfiguration{}, false
 	}
 
-	if baseURL, ok := cfg["base_url"]; ok {
-		config.BaseURL = baseURL.(string)
+	preset, ok := channelPresets[presetName]
+	if !ok || preset.Type != channelType {
+		return alerts.ChannelConfiguration{}, false
 	}
 
-	if channel, ok := cfg["channel"]; ok {
-		config.Channel = channel.(string)
+	defaults := preset.Default()
+	if !channelConfigurationMatchesPreset(defaults, c) {
+		return alerts.ChannelConfiguration{}, false
 	}
 
-	if key, ok := cfg["key"]; ok {
-		config.Key = key.(string)
+	return diffChannelConfiguration(defaults, c), true
+}
+
+func channelConfigurationMatchesPreset(defaults, c alerts.ChannelConfiguration) bool {
+	dv := reflect.ValueOf(defaults)
+	cv := reflect.ValueOf(c)
+
+	for i := 0; i < dv.NumField(); i++ {
+		df := dv.Field(i)
+		if df.IsZero() {
+			continue
+		}
+
+		if !reflect.DeepEqual(df.Interface(), cv.Field(i).Interface()) {
+			return false
+		}
 	}
 
-	if headers, ok := cfg["headers"]; ok {
-		h := headers.(map[string]interface{})
-		config.Headers = h
+	return true
+}
+
+// diffChannelConfiguration returns the fields of c that differ from
+// defaults, i.e. what the user overrode on top of the preset.
+func diffChannelConfiguration(defaults, c alerts.ChannelConfiguration) alerts.ChannelConfiguration {
+	overrides := alerts.ChannelConfiguration{}
+
+	dv := reflect.ValueOf(defaults)
+	cv := reflect.ValueOf(c)
+	ov := reflect.ValueOf(&overrides).Elem()
+
+	for i := 0; i < dv.NumField(); i++ {
+		if !reflect.DeepEqual(dv.Field(i).Interface(), cv.Field(i).Interface()) {
+			ov.Field(i).Set(cv.Field(i))
+		}
 	}
 
-	if headers, ok := cfg["headers_string"]; ok && headers != "" {
-		s := []byte(headers.(string))
-		var h map[string]interface{}
-		err := json.Unmarshal(s, &h)
+	return overrides
+}
 
-		if err != nil {
+// expandAlertChannelConfiguration decodes the HCL `config` block into the
+// per-type struct that matches channelType, validates it, and converts the
+// result into the alerts.ChannelConfiguration shape the API expects.
+// Using a dedicated struct per type means a field that doesn't belong to a
+// given channel (e.g. `service_key` on a `slack` channel) is rejected here
+// instead of silently being forwarded to the API.
+func expandAlertChannelConfiguration(channelType alerts.ChannelType, cfg map[string]interface{}) (*alerts.ChannelConfiguration, error) {
+	defer func() {
+		traceChannelConfig("expand", 0, channelType, hclConfigKeys(cfg), nil, nil, false)
+	}()
+
+	switch channelType {
+	case "webhook":
+		var c channelConfigWebhook
+		if err := decodeChannelConfig(cfg, &c); err != nil {
 			return nil, err
 		}
 
-		config.Headers = h
-	}
+		if err := c.validate(); err != nil {
+			return nil, err
+		}
 
-	if includeJSONAttachment, ok := cfg["include_json_attachment"]; ok {
-		config.IncludeJSONAttachment = includeJSONAttachment.(string)
-	}
+		config := alerts.ChannelConfiguration{
+			BaseURL:      c.BaseURL,
+			AuthUsername: c.AuthUsername,
+			AuthPassword: c.AuthPassword,
+			Headers:      c.Headers,
+			Payload:      c.Payload,
+			PayloadType:  c.PayloadType,
+		}
 
-	if payload, ok := cfg["payload"]; ok {
-		p := payload.(map[string]interface{})
-		config.Payload = p
-	}
+		if c.HeadersString != "" {
+			h, err := unmarshalChannelConfigJSON(c.HeadersString)
+			if err != nil {
+				return nil, err
+			}
+
+			config.Headers = h
+		}
 
-	if payload, ok := cfg["payload_string"]; ok && payload != "" {
-		s := []byte(payload.(string))
-		var p map[string]interface{}
-		err := json.Unmarshal(s, &p)
+		if c.PayloadString != "" {
+			p, err := unmarshalChannelConfigJSON(c.PayloadString)
+			if err != nil {
+				return nil, err
+			}
 
-		if err != nil {
+			config.Payload = p
+		}
+
+		// The raw template source, not a rendered payload, is what gets
+		// sent to New Relic here: this provider has no delivery path
+		// that interpolates a payload_template against a real incident
+		// (see webhookTemplateContext), so the `{{ ... }}` expressions
+		// reach the API verbatim regardless of
+		// render_templates_server_side.
+		if tmplSrc, err := c.payloadTemplateSource(); err != nil {
+			return nil, err
+		} else if tmplSrc != "" {
+			p, err := unmarshalChannelConfigJSON(tmplSrc)
+			if err != nil {
+				return nil, fmt.Errorf("payload_template must be valid JSON (template expressions included as plain string values): %w", err)
+			}
+
+			config.Payload = p
+
+			if config.PayloadType == "" {
+				config.PayloadType = "json"
+			}
+		}
+
+		return &config, nil
+	case "slack":
+		var c channelConfigSlack
+		if err := decodeChannelConfig(cfg, &c); err != nil {
 			return nil, err
 		}
 
-		config.Payload = p
-	}
+		if err := c.validate(); err != nil {
+			return nil, err
+		}
 
-	if payloadType, ok := cfg["payload_type"]; ok {
-		config.PayloadType = payloadType.(string)
-	}
+		return &alerts.ChannelConfiguration{
+			URL:     c.URL,
+			Channel: c.Channel,
+		}, nil
+	case "pagerduty":
+		var c channelConfigPagerDuty
+		if err := decodeChannelConfig(cfg, &c); err != nil {
+			return nil, err
+		}
 
-	if recipients, ok := cfg["recipients"]; ok {
-		config.Recipients = recipients.(string)
-	}
+		if err := c.validate(); err != nil {
+			return nil, err
+		}
+
+		return &alerts.ChannelConfiguration{
+			ServiceKey: c.ServiceKey,
+		}, nil
+	case "opsgenie":
+		var c channelConfigOpsGenie
+		if err := decodeChannelConfig(cfg, &c); err != nil {
+			return nil, err
+		}
 
-	if region, ok := cfg["region"]; ok {
-		config.Region = region.(string)
+		if err := c.validate(); err != nil {
+			return nil, err
+		}
+
+		return &alerts.ChannelConfiguration{
+			APIKey:     c.APIKey,
+			Teams:      c.Teams,
+			Tags:       c.Tags,
+			Recipients: c.Recipients,
+			Region:     c.Region,
+		}, nil
+	case "victorops":
+		var c channelConfigVictorOps
+		if err := decodeChannelConfig(cfg, &c); err != nil {
+			return nil, err
+		}
+
+		if err := c.validate(); err != nil {
+			return nil, err
+		}
+
+		return &alerts.ChannelConfiguration{
+			Key:      c.Key,
+			RouteKey: c.RouteKey,
+		}, nil
+	case "email":
+		var c channelConfigEmail
+		if err := decodeChannelConfig(cfg, &c); err != nil {
+			return nil, err
+		}
+
+		if err := c.validate(); err != nil {
+			return nil, err
+		}
+
+		return &alerts.ChannelConfiguration{
+			Recipients:            c.Recipients,
+			IncludeJSONAttachment: c.IncludeJSONAttachment,
+		}, nil
+	case "hipchat":
+		var c channelConfigHipChat
+		if err := decodeChannelConfig(cfg, &c); err != nil {
+			return nil, err
+		}
+
+		if err := c.validate(); err != nil {
+			return nil, err
+		}
+
+		return &alerts.ChannelConfiguration{
+			Key:     c.Key,
+			Channel: c.Channel,
+		}, nil
+	case "campfire":
+		var c channelConfigCampfire
+		if err := decodeChannelConfig(cfg, &c); err != nil {
+			return nil, err
+		}
+
+		if err := c.validate(); err != nil {
+			return nil, err
+		}
+
+		return &alerts.ChannelConfiguration{
+			Key:     c.Key,
+			Channel: c.Channel,
+		}, nil
+	case "user":
+		var c channelConfigUser
+		if err := decodeChannelConfig(cfg, &c); err != nil {
+			return nil, err
+		}
+
+		if err := c.validate(); err != nil {
+			return nil, err
+		}
+
+		return &alerts.ChannelConfiguration{
+			UserID: c.UserID,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported alert channel type: %s", channelType)
 	}
+}
+
+// decodeChannelConfig weak-decodes the raw HCL config map into the given
+// per-type struct. cfg is the single flat `config` map Terraform builds
+// from the schema shared by every channel type, so it always carries
+// every key, zero-valued for whichever type isn't in use. Keys that
+// belong to out's type are decoded normally; any other key is only
+// rejected if the user actually set it to a non-zero value, since that's
+// the only case where it doesn't belong on this channel type (e.g.
+// `service_key` set on a `slack` channel).
+func decodeChannelConfig(cfg map[string]interface{}, out interface{}) error {
+	allowed := channelConfigKeys(out)
+
+	filtered := make(map[string]interface{}, len(cfg))
+
+	for k, v := range cfg {
+		if allowed[k] {
+			filtered[k] = v
+			continue
+		}
 
-	if routeKey, ok := cfg["route_key"]; ok {
-		config.RouteKey = routeKey.(string)
+		if isEmptyConfigValue(v) {
+			continue
+		}
+
+		return fmt.Errorf("%q is not a valid config argument for this channel type", k)
 	}
 
-	if serviceKey, ok := cfg["service_key"]; ok {
-		config.ServiceKey = serviceKey.(string)
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		Result:           out,
+	})
+	if err != nil {
+		return err
 	}
 
-	if tags, ok := cfg["tags"]; ok {
-		config.Tags = tags.(string)
+	return decoder.Decode(filtered)
+}
+
+// channelConfigKeys returns the set of mapstructure tag names declared on
+// out's struct type.
+func channelConfigKeys(out interface{}) map[string]bool {
+	t := reflect.TypeOf(out).Elem()
+
+	keys := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("mapstructure")
+		if tag == "" {
+			continue
+		}
+
+		keys[strings.Split(tag, ",")[0]] = true
 	}
 
-	if teams, ok := cfg["teams"]; ok {
-		config.Teams = teams.(string)
+	return keys
+}
+
+// isEmptyConfigValue reports whether v is the zero value Terraform fills
+// an unset schema attribute with (e.g. "" for a string, nil/empty for a
+// map or list).
+func isEmptyConfigValue(v interface{}) bool {
+	if v == nil {
+		return true
 	}
 
-	if url, ok := cfg["url"]; ok {
-		config.URL = url.(string)
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.String, reflect.Map, reflect.Slice, reflect.Array:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	default:
+		return false
 	}
+}
 
-	if userID, ok := cfg["user_id"]; ok {
-		config.UserID = userID.(string)
+func unmarshalChannelConfigJSON(s string) (map[string]interface{}, error) {
+	var v map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return nil, err
 	}
 
-	return &config, nil
+	return v, nil
 }
 
 func expandAlertChannelIDs(channelIDs []interface{}) []int {
@@ -175,7 +959,21 @@ func flattenAlertChannel(channel *alerts.Channel, d *schema.ResourceData) error
 	d.Set("name", channel.Name)
 	d.Set("type", channel.Type)
 
-	config, err := flattenAlertChannelConfiguration(&channel.Configuration, d)
+	configuration := channel.Configuration
+
+	// Only ever round-trip as the preset this resource was already
+	// configured with; never infer a preset from configuration shape
+	// alone (see matchChannelPreset).
+	presetName, _ := d.Get("preset").(string)
+
+	if overrides, ok := matchChannelPreset(presetName, channel.Type, channel.Configuration); ok {
+		d.Set("preset", presetName)
+		configuration = overrides
+	} else {
+		d.Set("preset", "")
+	}
+
+	config, err := flattenAlertChannelConfiguration(channel.ID, channel.Type, &configuration, d)
 	if err != nil {
 		return err
 	}
@@ -187,64 +985,180 @@ func flattenAlertChannel(channel *alerts.Channel, d *schema.ResourceData) error
 	return nil
 }
 
-func flattenAlertChannelConfiguration(c *alerts.ChannelConfiguration, d *schema.ResourceData) ([]interface{}, error) {
+// flattenAlertChannelConfiguration converts an alerts.ChannelConfiguration
+// back into the per-type struct for channelType, merges back any
+// sensitive values the API doesn't return on GET, and encodes the result
+// into the flat map Terraform expects for the `config` block. It also
+// emits one traceChannelConfig line describing the round-trip, which is
+// the diagnostic signal that makes import-vs-refresh divergence (the
+// isImportState branch below) debuggable in the field.
+func flattenAlertChannelConfiguration(channelID int, channelType alerts.ChannelType, c *alerts.ChannelConfiguration, d *schema.ResourceData) ([]interface{}, error) {
 	if c == nil {
 		return nil, nil
 	}
 
-	configResult := make(map[string]interface{})
+	// Use the current state to detect if an import is being attempted.
+	// An empty config means TF doesn't know about it yet because at
+	// least one config attribute is required for a given channel type.
+	isImportState := len(d.State().Attributes["config"]) == 0
+
+	var mergedSensitiveKeys []string
+
+	defer func() {
+		traceChannelConfig("flatten", channelID, channelType, hclConfigKeys(rawAlertChannelConfig(d)), populatedConfigurationFields(*c), mergedSensitiveKeys, isImportState)
+	}()
+
+	switch channelType {
+	case "webhook":
+		typed := channelConfigWebhook{
+			BaseURL:      c.BaseURL,
+			AuthUsername: c.AuthUsername,
+			AuthPassword: c.AuthPassword,
+			Headers:      c.Headers,
+			Payload:      c.Payload,
+			PayloadType:  c.PayloadType,
+		}
 
-	// Conditionally sets some values the API deems sensitive
-	// on the configResult map based on what the user
-	// supplied in their config HCL.
-	setSensitiveConfigValues(configResult, c, d)
-
-	configResult["auth_username"] = c.AuthUsername
-	configResult["base_url"] = c.BaseURL
-	configResult["channel"] = c.Channel
-	configResult["include_json_attachment"] = c.IncludeJSONAttachment
-	configResult["payload_type"] = c.PayloadType
-	configResult["recipients"] = c.Recipients
-	configResult["region"] = c.Region
-	configResult["route_key"] = c.RouteKey
-	configResult["tags"] = c.Tags
-	configResult["teams"] = c.Teams
-	configResult["user_id"] = c.UserID
-
-	// Use the current state to detect if an import
-	// is being attempted.
-	state := d.State()
-
-	// An empty config means TF doesn't know about it yet because
-	// at least one config attribute is required for a given channel type.
-	isImportState := len(state.Attributes["config"]) == 0
-
-	headersString, headersStringOk := d.GetOk("config.0.header_string")
-	_, payloadStringOk := d.GetOk("config.0.payload_string")
+		mergedSensitiveKeys = mergeSensitiveConfigValues(d, &typed)
+
+		configResult, err := encodeChannelConfig(&typed)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := flattenWebhookHeadersAndPayload(configResult, c, d, isImportState); err != nil {
+			return nil, err
+		}
+
+		// The API has no concept of the tls block, so it never comes back
+		// on a GET; carry forward whatever is already in state the same
+		// way sensitive fields are preserved.
+		if tls, ok := d.GetOk("config.0.tls"); ok {
+			configResult["tls"] = tls
+		}
+
+		// The API only stores the expanded payload map, so it can't tell
+		// us whether it originated from payload_template(_file); carry
+		// the template source and its render toggle forward from state.
+		if v, ok := d.GetOk("config.0.payload_template"); ok {
+			configResult["payload_template"] = v
+		}
+
+		if v, ok := d.GetOk("config.0.payload_template_file"); ok {
+			configResult["payload_template_file"] = v
+		}
+
+		if v, ok := d.GetOk("config.0.render_templates_server_side"); ok {
+			configResult["render_templates_server_side"] = v
+		}
+
+		return []interface{}{configResult}, nil
+	case "slack":
+		typed := channelConfigSlack{URL: c.URL, Channel: c.Channel}
+		mergedSensitiveKeys = mergeSensitiveConfigValues(d, &typed)
+
+		configResult, err := encodeChannelConfig(&typed)
+		if err != nil {
+			return nil, err
+		}
+
+		return []interface{}{configResult}, nil
+	case "pagerduty":
+		typed := channelConfigPagerDuty{ServiceKey: c.ServiceKey}
+		mergedSensitiveKeys = mergeSensitiveConfigValues(d, &typed)
+
+		configResult, err := encodeChannelConfig(&typed)
+		if err != nil {
+			return nil, err
+		}
+
+		return []interface{}{configResult}, nil
+	case "opsgenie":
+		typed := channelConfigOpsGenie{
+			APIKey:     c.APIKey,
+			Teams:      c.Teams,
+			Tags:       c.Tags,
+			Recipients: c.Recipients,
+			Region:     c.Region,
+		}
+		mergedSensitiveKeys = mergeSensitiveConfigValues(d, &typed)
+
+		configResult, err := encodeChannelConfig(&typed)
+		if err != nil {
+			return nil, err
+		}
+
+		return []interface{}{configResult}, nil
+	case "victorops":
+		typed := channelConfigVictorOps{Key: c.Key, RouteKey: c.RouteKey}
+		mergedSensitiveKeys = mergeSensitiveConfigValues(d, &typed)
+
+		configResult, err := encodeChannelConfig(&typed)
+		if err != nil {
+			return nil, err
+		}
+
+		return []interface{}{configResult}, nil
+	case "email":
+		typed := channelConfigEmail{
+			Recipients:            c.Recipients,
+			IncludeJSONAttachment: c.IncludeJSONAttachment,
+		}
+
+		configResult, err := encodeChannelConfig(&typed)
+		if err != nil {
+			return nil, err
+		}
+
+		return []interface{}{configResult}, nil
+	case "hipchat":
+		typed := channelConfigHipChat{Key: c.Key, Channel: c.Channel}
+		mergedSensitiveKeys = mergeSensitiveConfigValues(d, &typed)
+
+		configResult, err := encodeChannelConfig(&typed)
+		if err != nil {
+			return nil, err
+		}
 
-	headers, headersOk := d.GetOk("config.0.headers")
-	_, _ = d.GetOk("config.0.payload")
+		return []interface{}{configResult}, nil
+	case "campfire":
+		typed := channelConfigCampfire{Key: c.Key, Channel: c.Channel}
+		mergedSensitiveKeys = mergeSensitiveConfigValues(d, &typed)
 
-	log.Print("\n\n **************************** \n")
-	log.Printf("\n IS IMPORT:       %+v  \n", isImportState)
-	log.Printf("\n HEADER STRING:   %+v - %+v - %+v \n", headersString, headersStringOk, c.Headers)
-	// log.Printf("\n PAYLOAD STRING:  %+v - %+v - %+v \n", payloadString, payloadStringOk, c.Payload)
-	log.Print("\n **************************** \n")
-	log.Printf("\n IS IMPORT:  %+v  \n", isImportState)
-	log.Printf("\n HEADER:     %+v - %+v \n", headers, headersOk)
-	// log.Printf("\n PAYLOAD:    %+v - %+v \n", payload, payloadOk)
-	log.Print("\n **************************** \n\n")
-	time.Sleep(7 * time.Second)
+		configResult, err := encodeChannelConfig(&typed)
+		if err != nil {
+			return nil, err
+		}
 
-	// if headersOk && !headersStringOk
+		return []interface{}{configResult}, nil
+	case "user":
+		typed := channelConfigUser{UserID: c.UserID}
+
+		configResult, err := encodeChannelConfig(&typed)
+		if err != nil {
+			return nil, err
+		}
+
+		return []interface{}{configResult}, nil
+	default:
+		return nil, fmt.Errorf("unsupported alert channel type: %s", channelType)
+	}
+}
+
+// flattenWebhookHeadersAndPayload preserves the string-vs-map shape the
+// user originally supplied for `headers`/`headers_string` and
+// `payload`/`payload_string`, the same way the shared flatten logic used
+// to before it was split out per channel type.
+func flattenWebhookHeadersAndPayload(configResult map[string]interface{}, c *alerts.ChannelConfiguration, d *schema.ResourceData, isImportState bool) error {
+	_, headersStringOk := d.GetOk("config.0.headers_string")
+	_, payloadStringOk := d.GetOk("config.0.payload_string")
 
 	if _, ok := d.GetOk("config.0.headers"); ok || isImportState && !headersStringOk {
 		configResult["headers"] = c.Headers
 	} else if _, ok := d.GetOk("config.0.headers_string"); ok {
 		h, err := json.Marshal(c.Headers)
-
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		configResult["headers_string"] = string(h)
@@ -253,70 +1167,80 @@ func flattenAlertChannelConfiguration(c *alerts.ChannelConfiguration, d *schema.
 	if _, ok := d.GetOk("config.0.payload"); ok || isImportState && !payloadStringOk {
 		configResult["payload"] = c.Payload
 	} else if _, ok := d.GetOk("config.0.payload_string"); ok || isImportState {
-		h, err := json.Marshal(c.Payload)
-
+		p, err := json.Marshal(c.Payload)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		configResult["payload_string"] = string(h)
+		configResult["payload_string"] = string(p)
 	}
 
-	return []interface{}{configResult}, nil
+	return nil
 }
 
-func validateChannelConfiguration(config alerts.ChannelConfiguration) error {
-	if len(config.Payload) != 0 && config.PayloadType == "" {
-		return errors.New("payload_type is required when using payload")
+// encodeChannelConfig converts a per-type config struct into the flat
+// map[string]interface{} shape Terraform expects for the `config` block,
+// using the same mapstructure tags expandAlertChannelConfiguration reads.
+func encodeChannelConfig(typed interface{}) (map[string]interface{}, error) {
+	configResult := make(map[string]interface{})
+
+	if err := mapstructure.Decode(typed, &configResult); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return configResult, nil
 }
 
-// The Rest API treats these fields as sensitive and does NOT
-// return them as part of the GET response.
-func setSensitiveConfigValues(
-	configResult map[string]interface{},
-	c *alerts.ChannelConfiguration,
-	d *schema.ResourceData,
-) {
-	if attr, ok := d.GetOk("config.0.auth_password"); ok {
-		if c.AuthPassword != "" {
-			configResult["auth_password"] = c.AuthPassword
-		} else {
-			configResult["auth_password"] = attr.(string)
+// mergeSensitiveConfigValues walks the fields of typed tagged
+// `sensitive:"true"` and, for any that came back empty from the API
+// (the REST API does not return sensitive fields on GET), fills them
+// back in from what the user last supplied in HCL. typed must be a
+// pointer to a channelConfig* struct. It returns the HCL keys that were
+// merged back in this way.
+func mergeSensitiveConfigValues(d *schema.ResourceData, typed interface{}) []string {
+	var merged []string
+
+	v := reflect.ValueOf(typed).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Tag.Get("sensitive") != "true" {
+			continue
 		}
-	}
 
-	if attr, ok := d.GetOk("config.0.api_key"); ok {
-		if c.APIKey != "" {
-			configResult["api_key"] = c.APIKey
-		} else {
-			configResult["api_key"] = attr.(string)
+		key, ok := field.Tag.Lookup("mapstructure")
+		if !ok {
+			continue
 		}
-	}
 
-	if attr, ok := d.GetOk("config.0.url"); ok {
-		if c.URL != "" {
-			configResult["url"] = c.URL
-		} else {
-			configResult["url"] = attr.(string)
+		fieldValue := v.Field(i)
+		if fieldValue.Kind() != reflect.String || fieldValue.String() != "" {
+			continue
 		}
-	}
 
-	if attr, ok := d.GetOk("config.0.key"); ok {
-		if c.Key != "" {
-			configResult["key"] = c.Key
-		} else {
-			configResult["key"] = attr.(string)
+		if attr, ok := d.GetOk("config.0." + key); ok {
+			fieldValue.SetString(attr.(string))
+			merged = append(merged, key)
 		}
 	}
 
-	if attr, ok := d.GetOk("config.0.service_key"); ok {
-		if c.ServiceKey != "" {
-			configResult["service_key"] = c.ServiceKey
-		} else {
-			configResult["service_key"] = attr.(string)
-		}
+	return merged
+}
+
+// rawAlertChannelConfig returns the raw HCL `config` block as a map, the
+// same way expandAlertChannel extracts it, for use by traceChannelConfig.
+func rawAlertChannelConfig(d *schema.ResourceData) map[string]interface{} {
+	config, ok := d.GetOk("config")
+	if !ok {
+		return nil
 	}
+
+	x := config.([]interface{})
+	if len(x) == 0 || x[0] == nil {
+		return nil
+	}
+
+	return x[0].(map[string]interface{})
 }